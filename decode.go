@@ -0,0 +1,311 @@
+package gexf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Decode reads a GEXF document from r and rebuilds it into a Graph. It
+// accepts documents produced by other GEXF tools (Gephi, NetworkX,
+// sigma.js), not just ones written by Encode.
+func Decode(r io.Reader) (*Graph, error) {
+	var gx gexf
+	if err := xml.NewDecoder(r).Decode(&gx); err != nil {
+		return nil, err
+	}
+
+	if gx.Graph == nil {
+		return nil, fmt.Errorf("gexf: document has no <graph> element")
+	}
+
+	return gx.Graph, nil
+}
+
+// UnmarshalXML decodes a `<graph>` element, accepting the node and edge
+// `<attributes>` blocks in either order and resolving `<attvalue>`
+// elements back into typed AttrValues.
+func (g *Graph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw struct {
+		Mode       string              `xml:"mode"`
+		EdgeType   string              `xml:"defaultedgetype"`
+		Attributes []rawAttributeBlock `xml:"attributes"`
+		Nodes      []rawNode           `xml:"nodes>node"`
+		Edges      []rawEdge           `xml:"edges>edge"`
+	}
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+
+	g.Mode = raw.Mode
+	g.EdgeType = raw.EdgeType
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "timeformat":
+			g.TimeFormat = a.Value
+		case "mode":
+			if g.Mode == "" {
+				g.Mode = a.Value
+			}
+		case "defaultedgetype":
+			if g.EdgeType == "" {
+				g.EdgeType = a.Value
+			}
+		}
+	}
+	g.attrTitleToID = make(map[string]string)
+	g.featureToID = make(map[interface{}]string)
+
+	nodeIDToType := make(map[string]Type)
+	nodeIDToTitle := make(map[string]string)
+	edgeIDToType := make(map[string]Type)
+	edgeIDToTitle := make(map[string]string)
+
+	for _, a := range raw.Attributes {
+		attrs := attributes{Class: a.Class}
+		for _, ra := range a.Attrs {
+			at := attribute{ID: ra.ID, Title: ra.Title, Type: ra.Type}
+			if ra.Default != nil {
+				v, err := coerceValue(*ra.Default, Type(ra.Type))
+				if err != nil {
+					return fmt.Errorf("gexf: attribute %q default: %w", ra.Title, err)
+				}
+				at.Default = v
+			}
+			attrs.Attrs = append(attrs.Attrs, at)
+		}
+
+		var idToTitle map[string]string
+		var idToType map[string]Type
+		switch attrs.Class {
+		case "node":
+			g.NodeAttrs = &attrs
+			idToTitle, idToType = nodeIDToTitle, nodeIDToType
+		case "edge":
+			g.EdgeAttrs = &attrs
+			idToTitle, idToType = edgeIDToTitle, edgeIDToType
+		default:
+			return fmt.Errorf("gexf: unknown attributes class %q", attrs.Class)
+		}
+
+		for _, at := range attrs.Attrs {
+			g.attrTitleToID[at.Title] = at.ID
+			idToTitle[at.ID] = at.Title
+			idToType[at.ID] = Type(at.Type)
+		}
+	}
+
+	for _, n := range raw.Nodes {
+		attr, err := resolveAttrValues(n.Attr, nodeIDToTitle, nodeIDToType)
+		if err != nil {
+			return fmt.Errorf("gexf: node %q: %w", n.ID, err)
+		}
+		if len(n.Spells) > 0 {
+			if err := g.AddNodeWithSpells(n.ID, n.Label, resolveSpells(n.Spells), attr); err != nil {
+				return fmt.Errorf("gexf: node %q: %w", n.ID, err)
+			}
+		} else {
+			g.AddNode(n.ID, n.Label, attr)
+		}
+		if applyNodeViz(&g.Nodes[len(g.Nodes)-1], n) {
+			g.usesViz = true
+		}
+	}
+
+	for _, e := range raw.Edges {
+		attr, err := resolveAttrValues(e.Attr, edgeIDToTitle, edgeIDToType)
+		if err != nil {
+			return fmt.Errorf("gexf: edge %q: %w", e.ID, err)
+		}
+		if len(e.Spells) > 0 {
+			if err := g.AddEdgeWithSpells(e.Source, e.Target, resolveSpells(e.Spells), attr); err != nil {
+				return fmt.Errorf("gexf: edge %q: %w", e.ID, err)
+			}
+		} else {
+			g.AddEdge(e.Source, e.Target, attr)
+		}
+		if applyEdgeViz(&g.Edges[len(g.Edges)-1], e) {
+			g.usesViz = true
+		}
+	}
+
+	return nil
+}
+
+// applyNodeViz copies any `viz` namespace hints read off n onto the node
+// already appended to the graph, so Decode round-trips viz data written by
+// AddNodeWithViz/WriteNodeWithViz. It reports whether any hint was present.
+func applyNodeViz(dst *node, n rawNode) bool {
+	if n.VizColor == nil && n.VizSize == nil && n.VizPosition == nil && n.VizShape == nil {
+		return false
+	}
+	if n.VizColor != nil {
+		dst.VizColor = &wireVizColor{R: n.VizColor.R, G: n.VizColor.G, B: n.VizColor.B, A: n.VizColor.A}
+	}
+	if n.VizSize != nil {
+		dst.VizSize = &wireVizSize{Value: n.VizSize.Value}
+	}
+	if n.VizPosition != nil {
+		dst.VizPosition = &wireVizPosition{X: n.VizPosition.X, Y: n.VizPosition.Y, Z: n.VizPosition.Z}
+	}
+	if n.VizShape != nil {
+		dst.VizShape = &wireVizShape{Value: n.VizShape.Value}
+	}
+	return true
+}
+
+// applyEdgeViz copies any `viz` namespace hints read off e onto the edge
+// already appended to the graph, so Decode round-trips viz data written by
+// AddEdgeWithViz/WriteEdgeWithViz. It reports whether any hint was present.
+func applyEdgeViz(dst *edge, e rawEdge) bool {
+	if e.VizColor == nil && e.VizSize == nil && e.VizShape == nil {
+		return false
+	}
+	if e.VizColor != nil {
+		dst.VizColor = &wireVizColor{R: e.VizColor.R, G: e.VizColor.G, B: e.VizColor.B, A: e.VizColor.A}
+	}
+	if e.VizSize != nil {
+		dst.VizSize = &wireVizSize{Value: e.VizSize.Value}
+	}
+	if e.VizShape != nil {
+		dst.VizShape = &wireVizShape{Value: e.VizShape.Value}
+	}
+	return true
+}
+
+// resolveSpells converts the decode-side spell shape into exported Spells.
+func resolveSpells(raw []rawSpell) []Spell {
+	out := make([]Spell, len(raw))
+	for i, s := range raw {
+		out[i] = Spell{Start: s.Start, End: s.End}
+	}
+	return out
+}
+
+// rawNode is the decode-side shape of a `<node>` element; unlike the
+// encode-side node type, attvalues are kept as raw strings until their
+// declared Type is known. Its viz fields are namespace-qualified, unlike
+// the encode side's literal "viz:" element names, because Go's decoder
+// resolves the `xmlns:viz` prefix to the real namespace URI on read.
+type rawNode struct {
+	ID          string          `xml:"id,attr"`
+	Label       string          `xml:"label,attr"`
+	Spells      []rawSpell      `xml:"spells>spell"`
+	Attr        []rawAttrValue  `xml:"attvalues>attvalue"`
+	VizColor    *rawVizColor    `xml:"http://www.gexf.net/1.2draft/viz color"`
+	VizSize     *rawVizSize     `xml:"http://www.gexf.net/1.2draft/viz size"`
+	VizPosition *rawVizPosition `xml:"http://www.gexf.net/1.2draft/viz position"`
+	VizShape    *rawVizShape    `xml:"http://www.gexf.net/1.2draft/viz shape"`
+}
+
+// rawEdge is the decode-side shape of an `<edge>` element.
+type rawEdge struct {
+	ID       string         `xml:"id,attr"`
+	Source   string         `xml:"source,attr"`
+	Target   string         `xml:"target,attr"`
+	Spells   []rawSpell     `xml:"spells>spell"`
+	Attr     []rawAttrValue `xml:"attvalues>attvalue"`
+	VizColor *rawVizColor   `xml:"http://www.gexf.net/1.2draft/viz color"`
+	VizSize  *rawVizSize    `xml:"http://www.gexf.net/1.2draft/viz size"`
+	VizShape *rawVizShape   `xml:"http://www.gexf.net/1.2draft/viz shape"`
+}
+
+// rawVizColor, rawVizSize, rawVizPosition, and rawVizShape are the
+// decode-side shapes of the `viz` namespace elements; see wireVizColor,
+// wireVizSize, wireVizPosition, and wireVizShape in viz.go for their
+// encode-side counterparts.
+type rawVizColor struct {
+	R uint8   `xml:"r,attr"`
+	G uint8   `xml:"g,attr"`
+	B uint8   `xml:"b,attr"`
+	A float64 `xml:"a,attr"`
+}
+
+type rawVizSize struct {
+	Value float64 `xml:"value,attr"`
+}
+
+type rawVizPosition struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+	Z float64 `xml:"z,attr"`
+}
+
+type rawVizShape struct {
+	Value string `xml:"value,attr"`
+}
+
+// rawAttrValue is the decode-side shape of an `<attvalue>` element, kept
+// as a string until coerced according to its attribute's declared Type.
+type rawAttrValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+	Start string `xml:"start,attr,omitempty"`
+	End   string `xml:"end,attr,omitempty"`
+}
+
+// rawSpell is the decode-side shape of a `<spell>` element.
+type rawSpell struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// rawAttributeBlock is the decode-side shape of a `class="node"` or
+// `class="edge"` `<attributes>` block.
+type rawAttributeBlock struct {
+	Class string         `xml:"class,attr"`
+	Attrs []rawAttribute `xml:"attribute"`
+}
+
+// rawAttribute is the decode-side shape of an `<attribute>` declaration;
+// unlike the encode-side attribute type, Default is kept as a *string
+// (nil when the element is absent) until coerced according to Type.
+type rawAttribute struct {
+	ID      string  `xml:"id,attr"`
+	Title   string  `xml:"title,attr"`
+	Type    string  `xml:"type,attr"`
+	Default *string `xml:"default"`
+}
+
+// resolveAttrValues turns the raw `for`/`value` pairs read off the wire
+// into typed AttrValues, looking up each attribute's title and type by id.
+func resolveAttrValues(raw []rawAttrValue, idToTitle map[string]string, idToType map[string]Type) ([]AttrValue, error) {
+	var out []AttrValue
+	for _, r := range raw {
+		title, ok := idToTitle[r.For]
+		if !ok {
+			return nil, fmt.Errorf("attvalue refers to undeclared attribute id %q", r.For)
+		}
+
+		v, err := coerceValue(r.Value, idToType[r.For])
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", title, err)
+		}
+
+		out = append(out, AttrValue{Title: title, Value: v, Start: r.Start, End: r.End})
+	}
+	return out, nil
+}
+
+// coerceValue parses s according to the GEXF attribute type t.
+func coerceValue(s string, t Type) (interface{}, error) {
+	switch t {
+	case Long:
+		return strconv.ParseInt(s, 10, 64)
+	case Double:
+		return strconv.ParseFloat(s, 64)
+	case Float:
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case Boolean:
+		return strconv.ParseBool(s)
+	case ListString:
+		return strings.Split(s, "|"), nil
+	case String, AnyURI:
+		return s, nil
+	default:
+		return s, nil
+	}
+}
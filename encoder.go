@@ -0,0 +1,327 @@
+package gexf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Meta holds the document-level metadata written to a GEXF document's
+// `<meta>` element.
+type Meta struct {
+	Creator string
+	Desc    string
+}
+
+// Encoder writes a GEXF document directly to an io.Writer, one token at a
+// time, without ever holding the full set of nodes or edges in memory.
+// It is the right tool for graphs with millions of nodes/edges, where
+// building a Graph first and calling Encode would require buffering
+// everything in slices. Calls must be made in order: WriteHeader, then
+// DeclareNodeAttrs/DeclareEdgeAttrs, then WriteNode/WriteEdge, then Close.
+type Encoder struct {
+	Mode       string
+	EdgeType   string
+	TimeFormat string
+
+	// UsesViz declares the `viz` namespace on the root element. It must be
+	// set before WriteHeader if any node or edge written afterwards carries
+	// viz rendering hints.
+	UsesViz bool
+
+	enc *xml.Encoder
+
+	attrTitleToID map[string]string
+
+	headerWritten bool
+	nodeAttrsDone bool
+	edgeAttrsDone bool
+	nodesStarted  bool
+	nodesOpen     bool
+	edgesStarted  bool
+	edgesOpen     bool
+	edgeCount     int
+}
+
+// NewEncoder returns an Encoder that writes tokens directly to w. Mode and
+// EdgeType default to "static" and "directed", matching NewGraph; set them
+// before calling WriteHeader to change them.
+func NewEncoder(w io.Writer) *Encoder {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "    ")
+
+	return &Encoder{
+		Mode:          "static",
+		EdgeType:      "directed",
+		enc:           enc,
+		attrTitleToID: make(map[string]string),
+	}
+}
+
+// WriteHeader writes the `<gexf>`, `<meta>`, and `<graph>` opening
+// elements. It must be called exactly once, before any other Encoder method.
+func (enc *Encoder) WriteHeader(m *Meta) error {
+	if enc.headerWritten {
+		return fmt.Errorf("gexf: WriteHeader called more than once")
+	}
+
+	gexfStart := xml.StartElement{
+		Name: xml.Name{Local: "gexf"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns"}, Value: "http://www.gexf.net/1.2draft"},
+			{Name: xml.Name{Local: "version"}, Value: "1.2"},
+		},
+	}
+	if enc.UsesViz {
+		gexfStart.Attr = append(gexfStart.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:viz"}, Value: vizNamespace})
+	}
+	if err := enc.enc.EncodeToken(gexfStart); err != nil {
+		return err
+	}
+
+	wm := meta{LastModified: time.Now().Format("2006-01-02")}
+	if m != nil {
+		wm.Creator = m.Creator
+		wm.Desc = m.Desc
+	}
+	if err := enc.enc.Encode(wm); err != nil {
+		return err
+	}
+
+	graphStart := xml.StartElement{Name: xml.Name{Local: "graph"}}
+	if enc.Mode == "dynamic" && enc.TimeFormat != "" {
+		graphStart.Attr = append(graphStart.Attr, xml.Attr{Name: xml.Name{Local: "timeformat"}, Value: enc.TimeFormat})
+	}
+	if err := enc.enc.EncodeToken(graphStart); err != nil {
+		return err
+	}
+
+	if err := enc.enc.EncodeElement(enc.Mode, xml.StartElement{Name: xml.Name{Local: "mode"}}); err != nil {
+		return err
+	}
+	if err := enc.enc.EncodeElement(enc.EdgeType, xml.StartElement{Name: xml.Name{Local: "defaultedgetype"}}); err != nil {
+		return err
+	}
+
+	enc.headerWritten = true
+	return nil
+}
+
+// DeclareNodeAttrs writes the node `<attributes>` block. It must be called
+// before the first WriteNode.
+func (enc *Encoder) DeclareNodeAttrs(attrs []Attr) error {
+	if enc.nodesStarted {
+		return fmt.Errorf("gexf: DeclareNodeAttrs called after WriteNode")
+	}
+	if enc.edgesStarted {
+		return fmt.Errorf("gexf: DeclareNodeAttrs called after WriteEdge")
+	}
+
+	block, err := enc.declareAttrs("node", attrs)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.enc.EncodeElement(block, xml.StartElement{Name: xml.Name{Local: "attributes"}}); err != nil {
+		return err
+	}
+
+	enc.nodeAttrsDone = true
+	return nil
+}
+
+// DeclareEdgeAttrs writes the edge `<attributes>` block. It must be called
+// before the first WriteEdge.
+func (enc *Encoder) DeclareEdgeAttrs(attrs []Attr) error {
+	if enc.edgesStarted {
+		return fmt.Errorf("gexf: DeclareEdgeAttrs called after WriteEdge")
+	}
+	if enc.nodesStarted {
+		return fmt.Errorf("gexf: DeclareEdgeAttrs called after WriteNode")
+	}
+
+	block, err := enc.declareAttrs("edge", attrs)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.enc.EncodeElement(block, xml.StartElement{Name: xml.Name{Local: "attributes"}}); err != nil {
+		return err
+	}
+
+	enc.edgeAttrsDone = true
+	return nil
+}
+
+// declareAttrs builds the wire attributes block for class ("node" or
+// "edge"), assigning each attribute the next sequential id.
+func (enc *Encoder) declareAttrs(class string, attrs []Attr) (attributes, error) {
+	block := attributes{Class: class}
+
+	for _, a := range attrs {
+		if _, ok := enc.attrTitleToID[a.Title]; ok {
+			return attributes{}, fmt.Errorf("attr '%s' defined multiple times", a.Title)
+		}
+
+		id := strconv.Itoa(len(enc.attrTitleToID))
+		block.Attrs = append(block.Attrs, attribute{
+			ID:      id,
+			Title:   a.Title,
+			Type:    string(a.Type),
+			Default: a.Default,
+		})
+		enc.attrTitleToID[a.Title] = id
+	}
+
+	return block, nil
+}
+
+// WriteNode writes a single node. The `<nodes>` container is opened on the
+// first call.
+func (enc *Encoder) WriteNode(id, label string, attr []AttrValue) error {
+	return enc.writeNode(node{
+		ID:    id,
+		Label: label,
+		Attr:  attrValuesToWire(enc.attrTitleToID, attr),
+	})
+}
+
+// WriteNodeWithSpells writes a node that exists only during the given time
+// intervals. It returns an error if the encoder is not in dynamic mode.
+func (enc *Encoder) WriteNodeWithSpells(id, label string, spells []Spell, attr []AttrValue) error {
+	if enc.Mode != "dynamic" {
+		return fmt.Errorf("gexf: spells require a dynamic graph, got mode %q", enc.Mode)
+	}
+
+	return enc.writeNode(node{
+		ID:     id,
+		Label:  label,
+		Spells: spellsToWire(spells),
+		Attr:   attrValuesToWire(enc.attrTitleToID, attr),
+	})
+}
+
+// WriteNodeWithViz writes a node carrying `viz` namespace rendering hints.
+// UsesViz must have been set before WriteHeader for the `xmlns:viz`
+// declaration to be present on the root element.
+func (enc *Encoder) WriteNodeWithViz(id, label string, viz *NodeViz, attr []AttrValue) error {
+	vc, vs, vp, vsh := nodeVizToWire(viz)
+
+	return enc.writeNode(node{
+		ID:          id,
+		Label:       label,
+		Attr:        attrValuesToWire(enc.attrTitleToID, attr),
+		VizColor:    vc,
+		VizSize:     vs,
+		VizPosition: vp,
+		VizShape:    vsh,
+	})
+}
+
+func (enc *Encoder) writeNode(n node) error {
+	if !enc.nodesStarted {
+		if err := enc.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "nodes"}}); err != nil {
+			return err
+		}
+		enc.nodesStarted = true
+		enc.nodesOpen = true
+	}
+
+	return enc.enc.EncodeElement(n, xml.StartElement{Name: xml.Name{Local: "node"}})
+}
+
+// WriteEdge writes a single edge. The `<edges>` container is opened (and
+// `<nodes>` closed, if open) on the first call.
+func (enc *Encoder) WriteEdge(from, to string, attr []AttrValue) error {
+	return enc.writeEdge(edge{
+		ID:     strconv.Itoa(enc.edgeCount),
+		Source: from,
+		Target: to,
+		Attr:   attrValuesToWire(enc.attrTitleToID, attr),
+	})
+}
+
+// WriteEdgeWithSpells writes an edge that exists only during the given time
+// intervals. It returns an error if the encoder is not in dynamic mode.
+func (enc *Encoder) WriteEdgeWithSpells(from, to string, spells []Spell, attr []AttrValue) error {
+	if enc.Mode != "dynamic" {
+		return fmt.Errorf("gexf: spells require a dynamic graph, got mode %q", enc.Mode)
+	}
+
+	return enc.writeEdge(edge{
+		ID:     strconv.Itoa(enc.edgeCount),
+		Source: from,
+		Target: to,
+		Spells: spellsToWire(spells),
+		Attr:   attrValuesToWire(enc.attrTitleToID, attr),
+	})
+}
+
+// WriteEdgeWithViz writes an edge carrying `viz` namespace rendering hints.
+// UsesViz must have been set before WriteHeader for the `xmlns:viz`
+// declaration to be present on the root element.
+func (enc *Encoder) WriteEdgeWithViz(from, to string, viz *EdgeViz, attr []AttrValue) error {
+	vc, vs, vsh := edgeVizToWire(viz)
+
+	return enc.writeEdge(edge{
+		ID:       strconv.Itoa(enc.edgeCount),
+		Source:   from,
+		Target:   to,
+		Attr:     attrValuesToWire(enc.attrTitleToID, attr),
+		VizColor: vc,
+		VizSize:  vs,
+		VizShape: vsh,
+	})
+}
+
+func (enc *Encoder) writeEdge(e edge) error {
+	if !enc.edgesStarted {
+		if enc.nodesOpen {
+			if err := enc.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "nodes"}}); err != nil {
+				return err
+			}
+			enc.nodesOpen = false
+		}
+		if err := enc.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "edges"}}); err != nil {
+			return err
+		}
+		enc.edgesStarted = true
+		enc.edgesOpen = true
+	}
+
+	if err := enc.enc.EncodeElement(e, xml.StartElement{Name: xml.Name{Local: "edge"}}); err != nil {
+		return err
+	}
+
+	enc.edgeCount++
+	return nil
+}
+
+// Close closes every element WriteHeader opened and flushes the underlying
+// xml.Encoder. It must be called exactly once, after all nodes and edges
+// have been written.
+func (enc *Encoder) Close() error {
+	if enc.nodesOpen {
+		if err := enc.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "nodes"}}); err != nil {
+			return err
+		}
+		enc.nodesOpen = false
+	}
+	if enc.edgesOpen {
+		if err := enc.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "edges"}}); err != nil {
+			return err
+		}
+		enc.edgesOpen = false
+	}
+
+	if err := enc.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "graph"}}); err != nil {
+		return err
+	}
+	if err := enc.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "gexf"}}); err != nil {
+		return err
+	}
+
+	return enc.enc.Flush()
+}
@@ -0,0 +1,302 @@
+package gexf
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format encodes a Graph to an output representation.
+type Format interface {
+	Encode(w io.Writer, g *Graph) error
+}
+
+// The built-in Formats.
+var (
+	GEXF      Format = gexfFormat{}
+	GraphML   Format = graphMLFormat{}
+	DOT       Format = dotFormat{}
+	JSONGraph Format = jsonGraphFormat{}
+)
+
+// EncodeAs encodes g to w using f.
+func EncodeAs(w io.Writer, g *Graph, f Format) error {
+	return f.Encode(w, g)
+}
+
+// FormatFromExt returns the Format implied by a filename's extension
+// (".gexf", ".graphml", ".dot"/".gv", ".json"). It returns an error if the
+// extension isn't one of the recognized formats.
+func FormatFromExt(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gexf":
+		return GEXF, nil
+	case ".graphml":
+		return GraphML, nil
+	case ".dot", ".gv":
+		return DOT, nil
+	case ".json":
+		return JSONGraph, nil
+	default:
+		return nil, fmt.Errorf("gexf: unrecognized format extension %q", filepath.Ext(name))
+	}
+}
+
+// gexfFormat is the existing GEXF Format, kept for symmetry with the other
+// Formats so all four are selectable through EncodeAs.
+type gexfFormat struct{}
+
+func (gexfFormat) Encode(w io.Writer, g *Graph) error {
+	return Encode(w, g)
+}
+
+// attrTitles returns the id-to-title lookup for a node or edge attributes
+// block, or nil if attrs is nil.
+func attrTitles(attrs *attributes) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+	titles := make(map[string]string, len(attrs.Attrs))
+	for _, a := range attrs.Attrs {
+		titles[a.ID] = a.Title
+	}
+	return titles
+}
+
+// resolveWireAttrValues turns a node or edge's wire-format attvalues into a
+// title-to-value map, suitable for formats that don't have GEXF's separate
+// attribute-declaration block.
+func resolveWireAttrValues(values *[]attrValue, titles map[string]string) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(*values))
+	for _, v := range *values {
+		title := titles[v.For]
+		if title == "" {
+			title = v.For
+		}
+		out[title] = v.Value
+	}
+	return out
+}
+
+// graphMLFormat encodes a Graph as GraphML.
+type graphMLFormat struct{}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	XMLName  xml.Name `xml:"key"`
+	ID       string   `xml:"id,attr"`
+	For      string   `xml:"for,attr"`
+	AttrName string   `xml:"attr.name,attr"`
+	AttrType string   `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	XMLName     xml.Name      `xml:"graph"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	XMLName xml.Name      `xml:"node"`
+	ID      string        `xml:"id,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	XMLName xml.Name      `xml:"edge"`
+	Source  string        `xml:"source,attr"`
+	Target  string        `xml:"target,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	XMLName xml.Name `xml:"data"`
+	Key     string   `xml:"key,attr"`
+	Value   string   `xml:",chardata"`
+}
+
+// graphMLType maps a GEXF attribute Type to its GraphML attr.type equivalent.
+func graphMLType(t string) string {
+	switch Type(t) {
+	case Long:
+		return "long"
+	case Double:
+		return "double"
+	case Float:
+		return "float"
+	case Boolean:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func (graphMLFormat) Encode(w io.Writer, g *Graph) error {
+	doc := graphMLDocument{
+		Graph: graphMLGraph{EdgeDefault: g.EdgeType},
+	}
+
+	if g.NodeAttrs != nil {
+		for _, a := range g.NodeAttrs.Attrs {
+			doc.Keys = append(doc.Keys, graphMLKey{ID: "n" + a.ID, For: "node", AttrName: a.Title, AttrType: graphMLType(a.Type)})
+		}
+	}
+	if g.EdgeAttrs != nil {
+		for _, a := range g.EdgeAttrs.Attrs {
+			doc.Keys = append(doc.Keys, graphMLKey{ID: "e" + a.ID, For: "edge", AttrName: a.Title, AttrType: graphMLType(a.Type)})
+		}
+	}
+
+	for _, n := range g.Nodes {
+		gn := graphMLNode{ID: n.ID}
+		if n.Attr != nil {
+			for _, v := range *n.Attr {
+				gn.Data = append(gn.Data, graphMLData{Key: "n" + v.For, Value: fmt.Sprint(v.Value)})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+
+	for _, e := range g.Edges {
+		ge := graphMLEdge{Source: e.Source, Target: e.Target}
+		if e.Attr != nil {
+			for _, v := range *e.Attr {
+				ge.Data = append(ge.Data, graphMLData{Key: "e" + v.For, Value: fmt.Sprint(v.Value)})
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, ge)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "    ")
+	return enc.Encode(doc)
+}
+
+// dotFormat encodes a Graph as Graphviz DOT.
+type dotFormat struct{}
+
+func (dotFormat) Encode(w io.Writer, g *Graph) error {
+	nodeTitles := attrTitles(g.NodeAttrs)
+	edgeTitles := attrTitles(g.EdgeAttrs)
+
+	edgeOp := "->"
+	graphKeyword := "digraph"
+	if g.EdgeType != "directed" {
+		edgeOp = "--"
+		graphKeyword = "graph"
+	}
+
+	if _, err := fmt.Fprintf(w, "%s G {\n", graphKeyword); err != nil {
+		return err
+	}
+
+	for _, n := range g.Nodes {
+		attrs := resolveWireAttrValues(n.Attr, nodeTitles)
+		if n.Label != "" {
+			if attrs == nil {
+				attrs = make(map[string]interface{}, 1)
+			}
+			attrs["label"] = n.Label
+		}
+		if _, err := fmt.Fprintf(w, "\t%q%s;\n", n.ID, dotBrackets(attrs)); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range g.Edges {
+		attrs := resolveWireAttrValues(e.Attr, edgeTitles)
+		if _, err := fmt.Fprintf(w, "\t%q %s %q%s;\n", e.Source, edgeOp, e.Target, dotBrackets(attrs)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprint(w, "}\n")
+	return err
+}
+
+// dotBrackets renders a DOT `[key="value", ...]` attribute list, or "" if
+// attrs is empty.
+func dotBrackets(attrs map[string]interface{}) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	titles := make([]string, 0, len(attrs))
+	for title := range attrs {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	var b strings.Builder
+	b.WriteString(" [")
+	for i, title := range titles {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%q", title, fmt.Sprint(attrs[title]))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// jsonGraphFormat encodes a Graph as the common `{nodes, edges}` JSON Graph
+// shape, with attribute values inlined rather than kept in GEXF's separate
+// declaration block.
+type jsonGraphFormat struct{}
+
+type jsonGraphDocument struct {
+	Directed bool            `json:"directed"`
+	Nodes    []jsonGraphNode `json:"nodes"`
+	Edges    []jsonGraphEdge `json:"edges"`
+}
+
+type jsonGraphNode struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type jsonGraphEdge struct {
+	Source     string                 `json:"source"`
+	Target     string                 `json:"target"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func (jsonGraphFormat) Encode(w io.Writer, g *Graph) error {
+	nodeTitles := attrTitles(g.NodeAttrs)
+	edgeTitles := attrTitles(g.EdgeAttrs)
+
+	doc := jsonGraphDocument{Directed: g.EdgeType == "directed"}
+
+	for _, n := range g.Nodes {
+		doc.Nodes = append(doc.Nodes, jsonGraphNode{
+			ID:         n.ID,
+			Label:      n.Label,
+			Attributes: resolveWireAttrValues(n.Attr, nodeTitles),
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Edges = append(doc.Edges, jsonGraphEdge{
+			Source:     e.Source,
+			Target:     e.Target,
+			Attributes: resolveWireAttrValues(e.Attr, edgeTitles),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(doc)
+}
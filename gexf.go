@@ -2,102 +2,142 @@
 package gexf
 
 import (
-	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"strconv"
-	"time"
 )
 
-// MarshalXML marshals a GEXF graph. This custom marshaler is needed to support duplicate `attributes` elements for node and edge.
-func (g Graph) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	start.Name.Local = "graph"
-	e.EncodeToken(start)
+// gexf is the root document element.
+type gexf struct {
+	XMLName   xml.Name `xml:"gexf"`
+	Namespace string   `xml:"xmlns,attr"`
+	Version   string   `xml:"version,attr"`
+	Meta      *meta    `xml:"meta"`
+	Graph     *Graph   `xml:"graph"`
+}
 
-	if err := e.EncodeElement(g.Mode, xml.StartElement{Name: xml.Name{Local: "mode"}}); err != nil {
-		return err
-	}
-	if err := e.EncodeElement(g.EdgeType, xml.StartElement{Name: xml.Name{Local: "defaultedgetype"}}); err != nil {
-		return err
-	}
+// meta carries the document-level metadata required by the GEXF spec.
+type meta struct {
+	XMLName      xml.Name `xml:"meta"`
+	LastModified string   `xml:"lastmodifieddate,attr"`
+	Creator      string   `xml:"creator"`
+	Desc         string   `xml:"description"`
+}
 
-	// handle NodeAttributes
-	nodeAttrsStartElement := xml.StartElement{Name: xml.Name{Local: "attributes"}}
-	e.EncodeElement(g.NodeAttrs, nodeAttrsStartElement)
+// attributes is a `class="node"` or `class="edge"` attribute declaration block.
+type attributes struct {
+	XMLName xml.Name    `xml:"attributes"`
+	Class   string      `xml:"class,attr"`
+	Attrs   []attribute `xml:"attribute"`
+}
 
-	// handle EdgeAttributes
-	edgeAttrsStartElement := xml.StartElement{Name: xml.Name{Local: "attributes"}}
-	e.EncodeElement(g.EdgeAttrs, edgeAttrsStartElement)
+// attribute declares a single node or edge attribute.
+type attribute struct {
+	XMLName xml.Name    `xml:"attribute"`
+	ID      string      `xml:"id,attr"`
+	Title   string      `xml:"title,attr"`
+	Type    string      `xml:"type,attr"`
+	Default interface{} `xml:"default,omitempty"`
+}
 
-	// Start the <nodes> element
-	nodesStartElement := xml.StartElement{Name: xml.Name{Local: "nodes"}}
-	if err := e.EncodeToken(nodesStartElement); err != nil {
-		return err
-	}
+// node is the wire representation of a graph node.
+type node struct {
+	XMLName     xml.Name         `xml:"node"`
+	ID          string           `xml:"id,attr"`
+	Label       string           `xml:"label,attr"`
+	Spells      *[]spell         `xml:"spells>spell,omitempty"`
+	Attr        *[]attrValue     `xml:"attvalues>attvalue,omitempty"`
+	VizColor    *wireVizColor    `xml:",omitempty"`
+	VizSize     *wireVizSize     `xml:",omitempty"`
+	VizPosition *wireVizPosition `xml:",omitempty"`
+	VizShape    *wireVizShape    `xml:",omitempty"`
+}
 
-	// Encode each Node within the <nodes> element
-	for _, node := range g.Nodes {
-		nodeStartElement := xml.StartElement{Name: xml.Name{Local: "node"}}
-		if err := e.EncodeElement(node, nodeStartElement); err != nil {
-			return err
-		}
-	}
+// edge is the wire representation of a graph edge.
+type edge struct {
+	XMLName  xml.Name      `xml:"edge"`
+	ID       string        `xml:"id,attr"`
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Spells   *[]spell      `xml:"spells>spell,omitempty"`
+	Attr     *[]attrValue  `xml:"attvalues>attvalue,omitempty"`
+	VizColor *wireVizColor `xml:",omitempty"`
+	VizSize  *wireVizSize  `xml:",omitempty"`
+	VizShape *wireVizShape `xml:",omitempty"`
+}
 
-	// End the <nodes> element
-	if err := e.EncodeToken(xml.EndElement{Name: nodesStartElement.Name}); err != nil {
-		return err
-	}
+// attrValue is a single `<attvalue>` entry resolved against an attribute's id.
+type attrValue struct {
+	XMLName xml.Name    `xml:"attvalue"`
+	For     string      `xml:"for,attr"`
+	Value   interface{} `xml:"value,attr"`
+	Start   string      `xml:"start,attr,omitempty"`
+	End     string      `xml:"end,attr,omitempty"`
+}
 
-	// Start the <edges> element
-	edgesStartElement := xml.StartElement{Name: xml.Name{Local: "edges"}}
-	if err := e.EncodeToken(edgesStartElement); err != nil {
-		return err
-	}
+// spell is a single `<spell>` entry within a node or edge's `<spells>` block.
+type spell struct {
+	XMLName xml.Name `xml:"spell"`
+	Start   string   `xml:"start,attr,omitempty"`
+	End     string   `xml:"end,attr,omitempty"`
+}
 
-	// Encode each Edge within the <edges> element
-	for _, edge := range g.Edges {
-		edgeStartElement := xml.StartElement{Name: xml.Name{Local: "edge"}}
-		if err := e.EncodeElement(edge, edgeStartElement); err != nil {
-			return err
-		}
-	}
+// Spell is a time interval during which a dynamic-mode node or edge exists
+// in the graph.
+type Spell struct {
+	Start string
+	End   string
+}
 
-	// End the <edges> element
-	if err := e.EncodeToken(xml.EndElement{Name: edgesStartElement.Name}); err != nil {
-		return err
+func spellsToWire(spells []Spell) *[]spell {
+	if len(spells) == 0 {
+		return nil
 	}
-
-	if err := e.EncodeToken(xml.EndElement{Name: start.Name}); err != nil {
-		return err
+	out := make([]spell, len(spells))
+	for i, s := range spells {
+		out[i] = spell{Start: s.Start, End: s.End}
 	}
-
-	return nil
+	return &out
 }
 
-// Encode encodes a graph to GEXF.
+// Encode encodes a graph to GEXF. It is a thin wrapper around Encoder for
+// callers that already have a fully built Graph in memory; for graphs too
+// large to hold in memory, use Encoder directly.
 func Encode(w io.Writer, g *Graph) error {
-	gx := gexf{
-		Namespace: "http://www.gexf.net/1.2draft",
-		Version:   "1.2",
-		Meta: &meta{
-			LastModified: time.Now().Format("2006-01-02"),
-			Creator:      "webscale!",
-			Desc:         "so fast!",
-		},
-		Graph: g,
-	}
+	enc := NewEncoder(w)
+	enc.Mode = g.Mode
+	enc.EdgeType = g.EdgeType
+	enc.TimeFormat = g.TimeFormat
+	enc.UsesViz = g.usesViz
 
-	data, err := xml.MarshalIndent(gx, "", "    ")
-	if err != nil {
+	if err := enc.WriteHeader(&Meta{Creator: "webscale!", Desc: "so fast!"}); err != nil {
 		return err
 	}
 
-	buf := bytes.NewBuffer(data)
-	_, err = io.Copy(w, buf)
+	if g.NodeAttrs != nil {
+		if err := enc.DeclareNodeAttrs(g.GetNodeAttrs()); err != nil {
+			return err
+		}
+	}
+	if g.EdgeAttrs != nil {
+		if err := enc.DeclareEdgeAttrs(g.GetEdgeAttrs()); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if err := enc.writeNode(n); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges {
+		if err := enc.writeEdge(e); err != nil {
+			return err
+		}
+	}
 
-	return err
-	// return xml.NewEncoder(w).Encode(gx)
+	return enc.Close()
 }
 
 // Attr is an attribute for a node or edge.
@@ -107,18 +147,23 @@ type Attr struct {
 	Default interface{}
 }
 
-// AttrValue is a value for an attribute.
+// AttrValue is a value for an attribute. Start and End are only meaningful
+// in a dynamic-mode graph, where they bound the interval during which the
+// value holds.
 type AttrValue struct {
 	Title string
 	Value interface{}
+	Start string
+	End   string
 }
 
 // Graph is a GEXF graph.
 type Graph struct {
 	XMLName xml.Name `xml:"graph"`
 
-	Mode     string `xml:"mode,attr,omitempty"`
-	EdgeType string `xml:"defaultedgetype,attr"`
+	Mode       string `xml:"mode,attr,omitempty"`
+	EdgeType   string `xml:"defaultedgetype,attr"`
+	TimeFormat string `xml:"-"`
 
 	Nodes     []node `xml:"nodes>node"`
 	NodeAttrs *attributes
@@ -128,6 +173,7 @@ type Graph struct {
 
 	attrTitleToID map[string]string
 	featureToID   map[interface{}]string
+	usesViz       bool
 }
 
 // NewGraph returns a new Graph.
@@ -140,6 +186,18 @@ func NewGraph() *Graph {
 	}
 }
 
+// NewDynamicGraph returns a new Graph in GEXF "dynamic" mode, timestamped
+// according to timeFormat ("double", "date", or "dateTime"). Dynamic graphs
+// may use AddNodeWithSpells and AddEdgeWithSpells to give nodes and edges
+// time intervals, and AttrValue's Start/End fields to give attribute values
+// time bounds.
+func NewDynamicGraph(timeFormat string) *Graph {
+	g := NewGraph()
+	g.Mode = "dynamic"
+	g.TimeFormat = timeFormat
+	return g
+}
+
 // GetNodeAttrs returns the attributes for nodes.
 func (g *Graph) GetNodeAttrs() []Attr {
 	var attrs []Attr
@@ -153,6 +211,19 @@ func (g *Graph) GetNodeAttrs() []Attr {
 	return attrs
 }
 
+// GetEdgeAttrs returns the attributes for edges.
+func (g *Graph) GetEdgeAttrs() []Attr {
+	var attrs []Attr
+	for _, a := range g.EdgeAttrs.Attrs {
+		attrs = append(attrs, Attr{
+			Title:   a.Title,
+			Type:    Type(a.Type),
+			Default: a.Default,
+		})
+	}
+	return attrs
+}
+
 // SetNodeAttrs sets the attributes for nodes.
 func (g *Graph) SetNodeAttrs(attrs []Attr) error {
 	g.NodeAttrs = &attributes{
@@ -182,22 +253,28 @@ func (g *Graph) AddNode(id, label string, attr []AttrValue) {
 	n := node{
 		ID:    id,
 		Label: label,
+		Attr:  attrValuesToWire(g.attrTitleToID, attr),
 	}
 
-	var values []attrValue
-	for _, a := range attr {
-		av := attrValue{
-			For:   g.attrTitleToID[a.Title],
-			Value: a.Value,
-		}
-		values = append(values, av)
+	g.Nodes = append(g.Nodes, n)
+}
+
+// AddNodeWithSpells adds a node that exists only during the given time
+// intervals. It returns an error if the graph is not in dynamic mode.
+func (g *Graph) AddNodeWithSpells(id, label string, spells []Spell, attr []AttrValue) error {
+	if g.Mode != "dynamic" {
+		return fmt.Errorf("gexf: spells require a dynamic graph, got mode %q", g.Mode)
 	}
 
-	if len(values) > 0 {
-		n.Attr = &values
+	n := node{
+		ID:     id,
+		Label:  label,
+		Spells: spellsToWire(spells),
+		Attr:   attrValuesToWire(g.attrTitleToID, attr),
 	}
 
 	g.Nodes = append(g.Nodes, n)
+	return nil
 }
 
 // AddEdge adds an edge to the graph.
@@ -206,22 +283,49 @@ func (g *Graph) AddEdge(from, to string, attr []AttrValue) {
 		ID:     strconv.Itoa(len(g.Edges)),
 		Source: from,
 		Target: to,
+		Attr:   attrValuesToWire(g.attrTitleToID, attr),
 	}
 
+	g.Edges = append(g.Edges, e)
+}
+
+// AddEdgeWithSpells adds an edge that exists only during the given time
+// intervals. It returns an error if the graph is not in dynamic mode.
+func (g *Graph) AddEdgeWithSpells(from, to string, spells []Spell, attr []AttrValue) error {
+	if g.Mode != "dynamic" {
+		return fmt.Errorf("gexf: spells require a dynamic graph, got mode %q", g.Mode)
+	}
+
+	e := edge{
+		ID:     strconv.Itoa(len(g.Edges)),
+		Source: from,
+		Target: to,
+		Spells: spellsToWire(spells),
+		Attr:   attrValuesToWire(g.attrTitleToID, attr),
+	}
+
+	g.Edges = append(g.Edges, e)
+	return nil
+}
+
+// attrValuesToWire converts AttrValues into their wire representation,
+// resolving each one's attribute id from a title-to-id map such as
+// Graph.attrTitleToID or Encoder.attrTitleToID.
+func attrValuesToWire(titleToID map[string]string, attr []AttrValue) *[]attrValue {
 	var values []attrValue
 	for _, a := range attr {
-		av := attrValue{
-			For:   g.attrTitleToID[a.Title],
+		values = append(values, attrValue{
+			For:   titleToID[a.Title],
 			Value: a.Value,
-		}
-		values = append(values, av)
+			Start: a.Start,
+			End:   a.End,
+		})
 	}
 
-	if len(values) > 0 {
-		e.Attr = &values
+	if len(values) == 0 {
+		return nil
 	}
-
-	g.Edges = append(g.Edges, e)
+	return &values
 }
 
 // SetEdgeAttrs sets the attributes for edges.
@@ -2,9 +2,15 @@ package gexf
 
 import (
 	"bytes"
+	"encoding/json"
+	"image/color"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
 )
 
 // TestNewGraph tests that NewGraph() returns a non-nil graph.
@@ -135,7 +141,7 @@ func TestEncode(t *testing.T) {
 	}
 
 	ee := `<gexf xmlns="http://www.gexf.net/1.2draft" version="1.2">
-    <meta lastmodifieddate="2024-01-15">
+    <meta lastmodifieddate="` + time.Now().Format("2006-01-02") + `">
         <creator>webscale!</creator>
         <description>so fast!</description>
     </meta>
@@ -185,3 +191,628 @@ func TestEncode(t *testing.T) {
 		t.Errorf("incorrect encoded graph %v", diff)
 	}
 }
+
+// TestDecode tests that Decode() rebuilds a graph encoded by Encode(),
+// including its mode, edge type, and attribute declarations with typed
+// defaults.
+func TestDecode(t *testing.T) {
+	g := NewGraph()
+	g.SetNodeAttrs([]Attr{
+		{Title: "a0", Type: String, Default: "foo"},
+		{Title: "a1", Type: Long, Default: 0},
+	})
+	g.SetEdgeAttrs([]Attr{
+		{Title: "a2", Type: Boolean, Default: false},
+	})
+	g.AddNode("1", "node 1", []AttrValue{{Title: "a0", Value: "BAR"}})
+	g.AddNode("2", "node 2", []AttrValue{{Title: "a1", Value: int64(2)}})
+	g.AddEdge("1", "2", []AttrValue{{Title: "a2", Value: true}})
+
+	var w bytes.Buffer
+	if err := Encode(&w, g); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Nodes) != 2 {
+		t.Errorf("Decode() returned %d nodes, want 2", len(got.Nodes))
+	}
+	if len(got.Edges) != 1 {
+		t.Errorf("Decode() returned %d edges, want 1", len(got.Edges))
+	}
+	if got.Mode != "static" || got.EdgeType != "directed" {
+		t.Errorf("Decode() returned mode=%q edgetype=%q", got.Mode, got.EdgeType)
+	}
+	if got.NodeAttrs.Attrs[0].Default != "foo" {
+		t.Errorf("Decode() node attr 0 default = %#v, want \"foo\"", got.NodeAttrs.Attrs[0].Default)
+	}
+	if got.NodeAttrs.Attrs[1].Default != int64(0) {
+		t.Errorf("Decode() node attr 1 default = %#v, want int64(0)", got.NodeAttrs.Attrs[1].Default)
+	}
+	if got.EdgeAttrs.Attrs[0].Default != false {
+		t.Errorf("Decode() edge attr 0 default = %#v, want false", got.EdgeAttrs.Attrs[0].Default)
+	}
+}
+
+// TestDecodeScopesAttrIDsPerClass tests that node and edge attribute ids are
+// resolved independently, so a node attribute and an edge attribute sharing
+// the same id (common in documents from other GEXF tools) don't collide.
+func TestDecodeScopesAttrIDsPerClass(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph mode="static" defaultedgetype="directed">
+    <attributes class="node">
+      <attribute id="0" title="weight" type="double"/>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="0" title="label" type="string"/>
+    </attributes>
+    <nodes>
+      <node id="1" label="node 1">
+        <attvalues>
+          <attvalue for="0" value="3.14"/>
+        </attvalues>
+      </node>
+      <node id="2" label="node 2"/>
+    </nodes>
+    <edges>
+      <edge id="0" source="1" target="2">
+        <attvalues>
+          <attvalue for="0" value="connects"/>
+        </attvalues>
+      </edge>
+    </edges>
+  </graph>
+</gexf>`
+
+	got, err := Decode(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeTitles := attrTitles(got.NodeAttrs)
+	nodeAttr := resolveWireAttrValues(got.Nodes[0].Attr, nodeTitles)
+	if nodeAttr["weight"] != float64(3.14) {
+		t.Errorf("node attvalue = %#v, want weight=float64(3.14)", nodeAttr)
+	}
+
+	edgeTitles := attrTitles(got.EdgeAttrs)
+	edgeAttr := resolveWireAttrValues(got.Edges[0].Attr, edgeTitles)
+	if edgeAttr["label"] != "connects" {
+		t.Errorf("edge attvalue = %#v, want label=\"connects\"", edgeAttr)
+	}
+}
+
+// TestAddNodeWithSpellsRequiresDynamic tests that spells are rejected on a
+// static graph.
+func TestAddNodeWithSpellsRequiresDynamic(t *testing.T) {
+	g := NewGraph()
+
+	err := g.AddNodeWithSpells("1", "node 1", []Spell{{Start: "1", End: "2"}}, nil)
+	if err == nil {
+		t.Error("AddNodeWithSpells() on a static graph did not return an error")
+	}
+}
+
+// TestNewDynamicGraph tests that dynamic graphs accept node and edge spells.
+func TestNewDynamicGraph(t *testing.T) {
+	g := NewDynamicGraph("date")
+
+	if err := g.AddNodeWithSpells("1", "node 1", []Spell{{Start: "2020-01-01", End: "2020-06-01"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	g.AddNode("2", "node 2", nil)
+
+	if err := g.AddEdgeWithSpells("1", "2", []Spell{{Start: "2020-02-01"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var w bytes.Buffer
+	if err := Encode(&w, g); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Mode != "dynamic" || got.TimeFormat != "date" {
+		t.Errorf("Decode() returned mode=%q timeformat=%q, want dynamic/date", got.Mode, got.TimeFormat)
+	}
+}
+
+// TestEncoderMatchesEncode tests that the streaming Encoder produces the
+// same document as building a Graph and calling Encode.
+func TestEncoderMatchesEncode(t *testing.T) {
+	g := NewGraph()
+	g.SetNodeAttrs([]Attr{{Title: "a0", Type: String, Default: "foo"}})
+	g.AddNode("1", "node 1", []AttrValue{{Title: "a0", Value: "BAR"}})
+
+	var want bytes.Buffer
+	if err := Encode(&want, g); err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	enc := NewEncoder(&got)
+	if err := enc.WriteHeader(&Meta{Creator: "webscale!", Desc: "so fast!"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.DeclareNodeAttrs([]Attr{{Title: "a0", Type: String, Default: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteNode("1", "node 1", []AttrValue{{Title: "a0", Value: "BAR"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(got.String(), want.String()); diff != "" {
+		t.Errorf("Encoder output does not match Encode() %v", diff)
+	}
+}
+
+// TestEncoderRejectsLateAttrDeclaration tests that declaring node attributes
+// after a node has already been written is rejected.
+func TestEncoderRejectsLateAttrDeclaration(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.WriteHeader(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteNode("1", "node 1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc.DeclareNodeAttrs([]Attr{{Title: "a0", Type: String}}); err == nil {
+		t.Error("DeclareNodeAttrs() after WriteNode did not return an error")
+	}
+}
+
+// TestEncoderRejectsLateAttrDeclarationAcrossClasses tests that declaring
+// attributes for one class after the other class has started writing is
+// rejected, not just declaring late for the same class.
+func TestEncoderRejectsLateAttrDeclarationAcrossClasses(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.WriteHeader(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteNode("1", "node 1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc.DeclareEdgeAttrs([]Attr{{Title: "a0", Type: String}}); err == nil {
+		t.Error("DeclareEdgeAttrs() after WriteNode did not return an error")
+	}
+
+	var buf2 bytes.Buffer
+	enc2 := NewEncoder(&buf2)
+	if err := enc2.WriteHeader(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc2.WriteEdge("1", "2", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc2.DeclareNodeAttrs([]Attr{{Title: "a0", Type: String}}); err == nil {
+		t.Error("DeclareNodeAttrs() after WriteEdge did not return an error")
+	}
+}
+
+// TestAddNodeWithVizDeclaresNamespace tests that a graph using viz hints
+// declares the viz namespace on the root element and emits its children.
+func TestAddNodeWithVizDeclaresNamespace(t *testing.T) {
+	g := NewGraph()
+	g.AddNodeWithViz("1", "node 1", &NodeViz{
+		Color:    color.RGBA{R: 255, G: 0, B: 0, A: 255},
+		Size:     2.5,
+		Position: Vec3{X: 1, Y: 2, Z: 3},
+		Shape:    "disc",
+	}, nil)
+
+	var w bytes.Buffer
+	if err := Encode(&w, g); err != nil {
+		t.Fatal(err)
+	}
+
+	out := w.String()
+	if !strings.Contains(out, `xmlns:viz="http://www.gexf.net/1.2draft/viz"`) {
+		t.Errorf("Encode() output missing viz namespace declaration: %s", out)
+	}
+	if !strings.Contains(out, `<viz:color r="255" g="0" b="0" a="1"></viz:color>`) {
+		t.Errorf("Encode() output missing viz:color: %s", out)
+	}
+	if !strings.Contains(out, `<viz:position x="1" y="2" z="3"></viz:position>`) {
+		t.Errorf("Encode() output missing viz:position: %s", out)
+	}
+}
+
+// TestEncoderWithVizMatchesGraph tests that the streaming Encoder's viz
+// output matches building a Graph with viz hints and calling Encode.
+func TestEncoderWithVizMatchesGraph(t *testing.T) {
+	viz := &EdgeViz{Color: color.RGBA{R: 0, G: 255, B: 0, A: 128}, Thickness: 3, Shape: "solid"}
+
+	g := NewGraph()
+	g.AddNode("1", "node 1", nil)
+	g.AddNode("2", "node 2", nil)
+	g.AddEdgeWithViz("1", "2", viz, nil)
+
+	var want bytes.Buffer
+	if err := Encode(&want, g); err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	enc := NewEncoder(&got)
+	enc.UsesViz = true
+	if err := enc.WriteHeader(&Meta{Creator: "webscale!", Desc: "so fast!"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteNode("1", "node 1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteNode("2", "node 2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.WriteEdgeWithViz("1", "2", viz, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(got.String(), want.String()); diff != "" {
+		t.Errorf("Encoder viz output does not match Encode() %v", diff)
+	}
+}
+
+// TestDecodeRoundTripsViz tests that viz namespace rendering hints written
+// by AddNodeWithViz/AddEdgeWithViz survive an Encode/Decode round trip.
+func TestDecodeRoundTripsViz(t *testing.T) {
+	nodeViz := &NodeViz{
+		Color:    color.RGBA{R: 255, G: 0, B: 0, A: 255},
+		Size:     2.5,
+		Position: Vec3{X: 1, Y: 2, Z: 3},
+		Shape:    "disc",
+	}
+	edgeViz := &EdgeViz{Color: color.RGBA{R: 0, G: 255, B: 0, A: 128}, Thickness: 3, Shape: "solid"}
+
+	g := NewGraph()
+	g.AddNodeWithViz("1", "node 1", nodeViz, nil)
+	g.AddNode("2", "node 2", nil)
+	g.AddEdgeWithViz("1", "2", edgeViz, nil)
+
+	var w bytes.Buffer
+	if err := Encode(&w, g); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := got.Nodes[0]
+	if n.VizColor == nil || n.VizSize == nil || n.VizPosition == nil || n.VizShape == nil {
+		t.Fatalf("Decode() dropped node viz hints: %#v", n)
+	}
+	if n.VizColor.R != 255 || n.VizShape.Value != "disc" || n.VizPosition.Z != 3 {
+		t.Errorf("Decode() node viz = %#v %#v %#v, want R=255 shape=disc z=3", n.VizColor, n.VizShape, n.VizPosition)
+	}
+
+	e := got.Edges[0]
+	if e.VizColor == nil || e.VizSize == nil || e.VizShape == nil {
+		t.Fatalf("Decode() dropped edge viz hints: %#v", e)
+	}
+	if e.VizColor.G != 255 || e.VizSize.Value != 3 || e.VizShape.Value != "solid" {
+		t.Errorf("Decode() edge viz = %#v %#v, want G=255 size=3 shape=solid", e.VizColor, e.VizSize)
+	}
+}
+
+// newFormatTestGraph returns a small graph with node and edge attributes,
+// used to exercise each output Format.
+func newFormatTestGraph() *Graph {
+	g := NewGraph()
+	g.SetNodeAttrs([]Attr{{Title: "a0", Type: String, Default: "foo"}})
+	g.SetEdgeAttrs([]Attr{{Title: "a1", Type: Long, Default: 0}})
+	g.AddNode("1", "node 1", []AttrValue{{Title: "a0", Value: "BAR"}})
+	g.AddNode("2", "node 2", nil)
+	g.AddEdge("1", "2", []AttrValue{{Title: "a1", Value: int64(5)}})
+	return g
+}
+
+// TestFormatFromExt tests that FormatFromExt maps known extensions to the
+// matching Format and rejects unknown ones.
+func TestFormatFromExt(t *testing.T) {
+	cases := []struct {
+		name string
+		want Format
+	}{
+		{"graph.gexf", GEXF},
+		{"graph.graphml", GraphML},
+		{"graph.dot", DOT},
+		{"graph.gv", DOT},
+		{"graph.json", JSONGraph},
+	}
+	for _, c := range cases {
+		got, err := FormatFromExt(c.name)
+		if err != nil {
+			t.Errorf("FormatFromExt(%q) returned error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("FormatFromExt(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if _, err := FormatFromExt("graph.txt"); err == nil {
+		t.Error("FormatFromExt(\"graph.txt\") did not return an error")
+	}
+}
+
+// TestEncodeAsGraphML tests that EncodeAs with GraphML emits `<key>`
+// declarations mirroring NodeAttrs/EdgeAttrs and inlines attvalues as `<data>`.
+func TestEncodeAsGraphML(t *testing.T) {
+	var w bytes.Buffer
+	if err := EncodeAs(&w, newFormatTestGraph(), GraphML); err != nil {
+		t.Fatal(err)
+	}
+
+	out := w.String()
+	for _, want := range []string{
+		`<key id="n0" for="node" attr.name="a0" attr.type="string"></key>`,
+		`<key id="e1" for="edge" attr.name="a1" attr.type="long"></key>`,
+		`<graph edgedefault="directed">`,
+		`<node id="1">`,
+		`<data key="n0">BAR</data>`,
+		`<edge source="1" target="2">`,
+		`<data key="e1">5</data>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("EncodeAs(GraphML) output missing %q: %s", want, out)
+		}
+	}
+}
+
+// TestEncodeAsDOT tests that EncodeAs with DOT maps attributes to bracket
+// lists and picks the graph keyword/edge operator from EdgeType.
+func TestEncodeAsDOT(t *testing.T) {
+	var w bytes.Buffer
+	if err := EncodeAs(&w, newFormatTestGraph(), DOT); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph G {
+	"1" [a0="BAR", label="node 1"];
+	"2" [label="node 2"];
+	"1" -> "2" [a1="5"];
+}
+`
+	if diff := cmp.Diff(w.String(), want); diff != "" {
+		t.Errorf("EncodeAs(DOT) output %v", diff)
+	}
+}
+
+// TestEncodeAsDOTUndirected tests that an undirected EdgeType switches DOT
+// to the "graph"/"--" form.
+func TestEncodeAsDOTUndirected(t *testing.T) {
+	g := NewGraph()
+	g.EdgeType = "undirected"
+	g.AddNode("1", "node 1", nil)
+	g.AddNode("2", "node 2", nil)
+	g.AddEdge("1", "2", nil)
+
+	var w bytes.Buffer
+	if err := EncodeAs(&w, g, DOT); err != nil {
+		t.Fatal(err)
+	}
+
+	out := w.String()
+	if !strings.HasPrefix(out, "graph G {") {
+		t.Errorf("EncodeAs(DOT) on undirected graph = %q, want graph keyword", out)
+	}
+	if !strings.Contains(out, `"1" -- "2"`) {
+		t.Errorf("EncodeAs(DOT) on undirected graph = %q, want -- edge operator", out)
+	}
+}
+
+// TestEncodeAsJSONGraph tests that EncodeAs with JSONGraph follows the
+// {nodes, edges} shape with attribute values inlined.
+func TestEncodeAsJSONGraph(t *testing.T) {
+	var w bytes.Buffer
+	if err := EncodeAs(&w, newFormatTestGraph(), JSONGraph); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Directed bool `json:"directed"`
+		Nodes    []struct {
+			ID         string                 `json:"id"`
+			Label      string                 `json:"label"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"nodes"`
+		Edges []struct {
+			Source     string                 `json:"source"`
+			Target     string                 `json:"target"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal(w.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Directed {
+		t.Error("EncodeAs(JSONGraph) directed = false, want true")
+	}
+	if len(got.Nodes) != 2 || len(got.Edges) != 1 {
+		t.Fatalf("EncodeAs(JSONGraph) got %d nodes, %d edges, want 2, 1", len(got.Nodes), len(got.Edges))
+	}
+	if got.Nodes[0].Attributes["a0"] != "BAR" {
+		t.Errorf("EncodeAs(JSONGraph) node 0 attributes = %v, want a0=BAR", got.Nodes[0].Attributes)
+	}
+	if got.Edges[0].Attributes["a1"] != float64(5) {
+		t.Errorf("EncodeAs(JSONGraph) edge 0 attributes = %v, want a1=5", got.Edges[0].Attributes)
+	}
+}
+
+// TestFromGonum tests that FromGonum walks a directed gonum graph into an
+// equivalent Graph.
+func TestFromGonum(t *testing.T) {
+	dg := simple.NewDirectedGraph()
+	dg.SetEdge(dg.NewEdge(simple.Node(1), simple.Node(2)))
+	dg.SetEdge(dg.NewEdge(simple.Node(2), simple.Node(3)))
+
+	g, err := FromGonum(dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.EdgeType != "directed" {
+		t.Errorf("FromGonum() EdgeType = %q, want directed", g.EdgeType)
+	}
+	if len(g.Nodes) != 3 {
+		t.Errorf("FromGonum() got %d nodes, want 3", len(g.Nodes))
+	}
+	if len(g.Edges) != 2 {
+		t.Errorf("FromGonum() got %d edges, want 2", len(g.Edges))
+	}
+}
+
+// TestFromGonumUndirectedDedupesEdges tests that FromGonum sets EdgeType to
+// "undirected" and emits each edge once, not once per endpoint.
+func TestFromGonumUndirectedDedupesEdges(t *testing.T) {
+	ug := simple.NewUndirectedGraph()
+	ug.SetEdge(ug.NewEdge(simple.Node(1), simple.Node(2)))
+
+	g, err := FromGonum(ug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.EdgeType != "undirected" {
+		t.Errorf("FromGonum() EdgeType = %q, want undirected", g.EdgeType)
+	}
+	if len(g.Edges) != 1 {
+		t.Errorf("FromGonum() got %d edges, want 1", len(g.Edges))
+	}
+}
+
+// gonumTestNode is a gonum node that carries its own AttrValues, used to
+// test that FromGonum reads Attributes() directly.
+type gonumTestNode struct {
+	id    int64
+	attrs []AttrValue
+}
+
+func (n gonumTestNode) ID() int64               { return n.id }
+func (n gonumTestNode) Attributes() []AttrValue { return n.attrs }
+
+// TestFromGonumReadsAttributes tests that FromGonum carries over attributes
+// from nodes implementing `Attributes() []AttrValue`.
+func TestFromGonumReadsAttributes(t *testing.T) {
+	dg := simple.NewDirectedGraph()
+	n1 := gonumTestNode{id: 1, attrs: []AttrValue{{Title: "label", Value: "hub"}}}
+	n2 := gonumTestNode{id: 2}
+	dg.SetEdge(simple.Edge{F: n1, T: n2})
+
+	g, err := FromGonum(dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := g.GetID(n1.ID())
+	var found bool
+	for _, n := range g.Nodes {
+		if n.ID == id && n.Attr != nil && len(*n.Attr) == 1 && (*n.Attr)[0].Value == "hub" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("FromGonum() did not carry Attributes() onto node %q", id)
+	}
+	if g.NodeAttrs == nil || len(g.NodeAttrs.Attrs) != 1 || g.NodeAttrs.Attrs[0].Title != "label" {
+		t.Errorf("FromGonum() did not declare a \"label\" node attribute, got %#v", g.NodeAttrs)
+	}
+
+	var w bytes.Buffer
+	if err := Encode(&w, g); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(&w); err != nil {
+		t.Errorf("Decode() of FromGonum() output failed: %v", err)
+	}
+}
+
+// TestFromGonumAttributeMapper tests that FromGonum falls back to the
+// configured AttributeMapper for nodes without Attributes().
+func TestFromGonumAttributeMapper(t *testing.T) {
+	dg := simple.NewDirectedGraph()
+	dg.SetEdge(dg.NewEdge(simple.Node(1), simple.Node(2)))
+
+	mapper := func(n graph.Node) []AttrValue {
+		return []AttrValue{{Title: "id", Value: n.ID()}}
+	}
+
+	g, err := FromGonum(dg, WithAttributeMapper(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range g.Nodes {
+		if n.Attr == nil || len(*n.Attr) != 1 {
+			t.Errorf("FromGonum() node %q missing mapped attribute", n.ID)
+		}
+	}
+}
+
+// TestToGonum tests that ToGonum converts a Graph into a gonum graph of the
+// right kind, with nodes carrying their gexf attributes.
+func TestToGonum(t *testing.T) {
+	g := NewGraph()
+	g.SetNodeAttrs([]Attr{{Title: "a0", Type: String}})
+	g.AddNode("1", "node 1", []AttrValue{{Title: "a0", Value: "BAR"}})
+	g.AddNode("2", "node 2", nil)
+	g.AddEdge("1", "2", nil)
+
+	gg := ToGonum(g)
+	dg, ok := gg.(*simple.DirectedGraph)
+	if !ok {
+		t.Fatalf("ToGonum() returned %T, want *simple.DirectedGraph", gg)
+	}
+	if dg.Nodes().Len() != 2 {
+		t.Errorf("ToGonum() got %d nodes, want 2", dg.Nodes().Len())
+	}
+
+	var sawAttr bool
+	nodes := dg.Nodes()
+	for nodes.Next() {
+		if n, ok := nodes.Node().(gonumNode); ok && len(n.attrs) == 1 && n.attrs[0].Value == "BAR" {
+			sawAttr = true
+		}
+	}
+	if !sawAttr {
+		t.Error("ToGonum() did not carry node attributes onto the gonum graph")
+	}
+}
+
+// TestToGonumUndirected tests that an undirected EdgeType produces a
+// *simple.UndirectedGraph.
+func TestToGonumUndirected(t *testing.T) {
+	g := NewGraph()
+	g.EdgeType = "undirected"
+	g.AddNode("1", "node 1", nil)
+	g.AddNode("2", "node 2", nil)
+	g.AddEdge("1", "2", nil)
+
+	gg := ToGonum(g)
+	if _, ok := gg.(*simple.UndirectedGraph); !ok {
+		t.Errorf("ToGonum() returned %T, want *simple.UndirectedGraph", gg)
+	}
+}
@@ -0,0 +1,199 @@
+package gexf
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// AttributeMapper extracts the AttrValues FromGonum attaches to a gexf node,
+// for gonum nodes that don't implement `Attributes() []AttrValue` directly.
+type AttributeMapper func(n graph.Node) []AttrValue
+
+// Option configures FromGonum.
+type Option func(*fromGonumOptions)
+
+type fromGonumOptions struct {
+	attrs AttributeMapper
+}
+
+// WithAttributeMapper sets the AttributeMapper FromGonum falls back to for
+// nodes that don't implement `Attributes() []AttrValue` themselves.
+func WithAttributeMapper(m AttributeMapper) Option {
+	return func(o *fromGonumOptions) { o.attrs = m }
+}
+
+// attrsFor returns the AttrValues for n: its own Attributes() if it
+// implements that interface, otherwise the configured AttributeMapper's
+// result, otherwise nil.
+func (o *fromGonumOptions) attrsFor(n graph.Node) []AttrValue {
+	if a, ok := n.(interface{ Attributes() []AttrValue }); ok {
+		return a.Attributes()
+	}
+	if o.attrs != nil {
+		return o.attrs(n)
+	}
+	return nil
+}
+
+// FromGonum converts a gonum graph.Graph into a Graph, walking its nodes
+// and edges via the gonum iterator interfaces and assigning each gonum
+// node a stable string id via GetID. The result is undirected if g
+// implements graph.Undirected, directed otherwise. Node attributes are
+// read off nodes implementing `Attributes() []AttrValue`, or via opts'
+// AttributeMapper, and declared via SetNodeAttrs before any node is added
+// so their attvalues resolve to real attribute ids.
+func FromGonum(g graph.Graph, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, fmt.Errorf("gexf: FromGonum requires a non-nil graph")
+	}
+
+	cfg := &fromGonumOptions{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	out := NewGraph()
+	_, undirected := g.(graph.Undirected)
+	if undirected {
+		out.EdgeType = "undirected"
+	}
+
+	var gnodes []graph.Node
+	nodeAttrs := make(map[int64][]AttrValue)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		n := nodes.Node()
+		gnodes = append(gnodes, n)
+		nodeAttrs[n.ID()] = cfg.attrsFor(n)
+	}
+
+	if attrs := declaredAttrs(gnodes, nodeAttrs); len(attrs) > 0 {
+		if err := out.SetNodeAttrs(attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, n := range gnodes {
+		id := out.GetID(n.ID())
+		out.AddNode(id, id, nodeAttrs[n.ID()])
+	}
+
+	// seen dedupes undirected edges, which g.From reports from both endpoints.
+	seen := make(map[[2]int64]bool)
+	for _, u := range gnodes {
+		to := g.From(u.ID())
+		for to.Next() {
+			v := to.Node()
+			if undirected {
+				key := [2]int64{u.ID(), v.ID()}
+				if u.ID() > v.ID() {
+					key = [2]int64{v.ID(), u.ID()}
+				}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			out.AddEdge(out.GetID(u.ID()), out.GetID(v.ID()), nil)
+		}
+	}
+
+	return out, nil
+}
+
+// declaredAttrs collects the distinct attribute titles seen across nodes,
+// in first-seen order, inferring each one's Type from its first value.
+func declaredAttrs(nodes []graph.Node, attrsByID map[int64][]AttrValue) []Attr {
+	var attrs []Attr
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		for _, av := range attrsByID[n.ID()] {
+			if seen[av.Title] {
+				continue
+			}
+			seen[av.Title] = true
+			attrs = append(attrs, Attr{Title: av.Title, Type: inferType(av.Value)})
+		}
+	}
+	return attrs
+}
+
+// inferType guesses the GEXF attribute Type for a Go value, since gonum
+// nodes carry no declared attribute types of their own.
+func inferType(v interface{}) Type {
+	switch v.(type) {
+	case int64, int:
+		return Long
+	case float64:
+		return Double
+	case float32:
+		return Float
+	case bool:
+		return Boolean
+	case []string:
+		return ListString
+	default:
+		return String
+	}
+}
+
+// gonumNode is the concrete graph.Node ToGonum creates. It implements
+// `Attributes() []AttrValue` so callers can recover a node's gexf
+// attributes after conversion.
+type gonumNode struct {
+	id    int64
+	attrs []AttrValue
+}
+
+func (n gonumNode) ID() int64               { return n.id }
+func (n gonumNode) Attributes() []AttrValue { return n.attrs }
+
+// ToGonum converts g into a gonum graph.Graph: a *simple.UndirectedGraph if
+// g.EdgeType is "undirected", or a *simple.DirectedGraph for any other
+// EdgeType, including the default "directed". Nodes are assigned int64 ids
+// in g.Nodes order.
+func ToGonum(g *Graph) graph.Graph {
+	var out interface {
+		graph.Graph
+		AddNode(graph.Node)
+		SetEdge(graph.Edge)
+	}
+	if g.EdgeType == "undirected" {
+		out = simple.NewUndirectedGraph()
+	} else {
+		out = simple.NewDirectedGraph()
+	}
+
+	nodeTitles := attrTitles(g.NodeAttrs)
+	nodesByGexfID := make(map[string]gonumNode, len(g.Nodes))
+	for i, n := range g.Nodes {
+		gn := gonumNode{id: int64(i), attrs: wireAttrsToAttrValues(n.Attr, nodeTitles)}
+		nodesByGexfID[n.ID] = gn
+		out.AddNode(gn)
+	}
+
+	for _, e := range g.Edges {
+		out.SetEdge(simple.Edge{F: nodesByGexfID[e.Source], T: nodesByGexfID[e.Target]})
+	}
+
+	return out
+}
+
+// wireAttrsToAttrValues turns a node or edge's wire-format attvalues back
+// into exported AttrValues, resolving each one's title from idToTitle.
+func wireAttrsToAttrValues(values *[]attrValue, idToTitle map[string]string) []AttrValue {
+	if values == nil {
+		return nil
+	}
+	out := make([]AttrValue, 0, len(*values))
+	for _, v := range *values {
+		title := idToTitle[v.For]
+		if title == "" {
+			title = v.For
+		}
+		out = append(out, AttrValue{Title: title, Value: v.Value, Start: v.Start, End: v.End})
+	}
+	return out
+}
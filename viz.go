@@ -0,0 +1,113 @@
+package gexf
+
+import (
+	"encoding/xml"
+	"image/color"
+	"strconv"
+)
+
+// vizNamespace is the XML namespace Gephi and sigma.js use for the
+// rendering hints (color, size, position, shape) declared in this file.
+const vizNamespace = "http://www.gexf.net/1.2draft/viz"
+
+// Vec3 is a 3D position, used by NodeViz.Position.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+// NodeViz holds the `viz` namespace rendering hints Gephi and sigma.js
+// read off a node: its color, size, position, and shape.
+type NodeViz struct {
+	Color    color.RGBA
+	Size     float64
+	Position Vec3
+	Shape    string
+}
+
+// EdgeViz holds the `viz` namespace rendering hints Gephi and sigma.js
+// read off an edge: its color, thickness, and shape.
+type EdgeViz struct {
+	Color     color.RGBA
+	Thickness float64
+	Shape     string
+}
+
+// wireVizColor is `<viz:color r="…" g="…" b="…" a="…"/>`.
+type wireVizColor struct {
+	XMLName xml.Name `xml:"viz:color"`
+	R       uint8    `xml:"r,attr"`
+	G       uint8    `xml:"g,attr"`
+	B       uint8    `xml:"b,attr"`
+	A       float64  `xml:"a,attr"`
+}
+
+// wireVizSize is `<viz:size value="…"/>`.
+type wireVizSize struct {
+	XMLName xml.Name `xml:"viz:size"`
+	Value   float64  `xml:"value,attr"`
+}
+
+// wireVizPosition is `<viz:position x="…" y="…" z="…"/>`.
+type wireVizPosition struct {
+	XMLName xml.Name `xml:"viz:position"`
+	X       float64  `xml:"x,attr"`
+	Y       float64  `xml:"y,attr"`
+	Z       float64  `xml:"z,attr"`
+}
+
+// wireVizShape is `<viz:shape value="…"/>`.
+type wireVizShape struct {
+	XMLName xml.Name `xml:"viz:shape"`
+	Value   string   `xml:"value,attr"`
+}
+
+func nodeVizToWire(v *NodeViz) (*wireVizColor, *wireVizSize, *wireVizPosition, *wireVizShape) {
+	if v == nil {
+		return nil, nil, nil, nil
+	}
+	return &wireVizColor{R: v.Color.R, G: v.Color.G, B: v.Color.B, A: float64(v.Color.A) / 255},
+		&wireVizSize{Value: v.Size},
+		&wireVizPosition{X: v.Position.X, Y: v.Position.Y, Z: v.Position.Z},
+		&wireVizShape{Value: v.Shape}
+}
+
+func edgeVizToWire(v *EdgeViz) (*wireVizColor, *wireVizSize, *wireVizShape) {
+	if v == nil {
+		return nil, nil, nil
+	}
+	return &wireVizColor{R: v.Color.R, G: v.Color.G, B: v.Color.B, A: float64(v.Color.A) / 255},
+		&wireVizSize{Value: v.Thickness},
+		&wireVizShape{Value: v.Shape}
+}
+
+// AddNodeWithViz adds a node carrying `viz` namespace rendering hints.
+func (g *Graph) AddNodeWithViz(id, label string, viz *NodeViz, attr []AttrValue) {
+	vc, vs, vp, vsh := nodeVizToWire(viz)
+	g.usesViz = true
+
+	g.Nodes = append(g.Nodes, node{
+		ID:          id,
+		Label:       label,
+		Attr:        attrValuesToWire(g.attrTitleToID, attr),
+		VizColor:    vc,
+		VizSize:     vs,
+		VizPosition: vp,
+		VizShape:    vsh,
+	})
+}
+
+// AddEdgeWithViz adds an edge carrying `viz` namespace rendering hints.
+func (g *Graph) AddEdgeWithViz(from, to string, viz *EdgeViz, attr []AttrValue) {
+	vc, vs, vsh := edgeVizToWire(viz)
+	g.usesViz = true
+
+	g.Edges = append(g.Edges, edge{
+		ID:       strconv.Itoa(len(g.Edges)),
+		Source:   from,
+		Target:   to,
+		Attr:     attrValuesToWire(g.attrTitleToID, attr),
+		VizColor: vc,
+		VizSize:  vs,
+		VizShape: vsh,
+	})
+}